@@ -0,0 +1,124 @@
+// Package actions emits the files and workflow commands a GitHub Actions
+// step expects: a Markdown step summary, step outputs, and ::error::
+// annotations. See https://docs.github.com/en/actions for the formats.
+package actions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Action writes to the step summary and output files named by the
+// environment. New reads that environment directly; tests use newAction to
+// inject a fake env and error writer instead.
+type Action struct {
+	summaryPath string
+	outputPath  string
+	errOut      io.Writer
+}
+
+func New() *Action {
+	return newAction(os.Getenv, os.Stdout)
+}
+
+func newAction(getenv func(string) string, errOut io.Writer) *Action {
+	return &Action{
+		summaryPath: getenv("GITHUB_STEP_SUMMARY"),
+		outputPath:  getenv("GITHUB_OUTPUT"),
+		errOut:      errOut,
+	}
+}
+
+// Row is one line of a rating's top-N table.
+type Row struct {
+	Rank   int
+	Rating float64
+	Detail string
+}
+
+// AppendSummaryTable appends a Markdown table for one rating to
+// $GITHUB_STEP_SUMMARY. It's a no-op if the step summary path isn't set.
+func (a *Action) AppendSummaryTable(title string, rows []Row) error {
+	if a.summaryPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(a.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		// TODO: log error
+	}()
+
+	if _, err := fmt.Fprintf(file, "\n### %s\n\n| Rank | Rating | Detail |\n| --- | --- | --- |\n", title); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(file, "| %d | %.2f | %s |\n", row.Rank, row.Rating, row.Detail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetOutput writes an output to $GITHUB_OUTPUT, using the same heredoc
+// encoding as SetJSONOutput. It's a no-op if the output path isn't set.
+func (a *Action) SetOutput(name, value string) error {
+	return a.writeOutput(name, value)
+}
+
+// SetJSONOutput marshals value and writes it as a multiline output, using
+// GitHub's heredoc encoding (name<<DELIM\n...\nDELIM\n).
+func (a *Action) SetJSONOutput(name string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return a.writeOutput(name, string(raw))
+}
+
+func (a *Action) writeOutput(name, value string) error {
+	if a.outputPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(a.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		// TODO: log error
+	}()
+
+	delimiter, err := randomDelimiter()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(file, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+	return err
+}
+
+// EmitError prints a ::error:: workflow command, surfacing err on the PR
+// check that ran this step.
+func (a *Action) EmitError(err error) {
+	fmt.Fprintf(a.errOut, "::error::%s\n", err)
+}
+
+// randomDelimiter avoids a heredoc collision if a value happens to contain
+// the delimiter text, as GitHub's own docs recommend.
+func randomDelimiter() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "ghadelim_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,99 @@
+package actions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeEnv(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func TestAction_NoOpWithoutPaths(t *testing.T) {
+	var errOut bytes.Buffer
+	action := newAction(fakeEnv(nil), &errOut)
+
+	if err := action.AppendSummaryTable("Top users", []Row{{Rank: 1, Rating: 3, Detail: "x"}}); err != nil {
+		t.Fatalf("AppendSummaryTable: %v", err)
+	}
+	if err := action.SetOutput("top_user_id", "42"); err != nil {
+		t.Fatalf("SetOutput: %v", err)
+	}
+}
+
+func TestAction_AppendSummaryTable(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	action := newAction(fakeEnv(map[string]string{"GITHUB_STEP_SUMMARY": summaryPath}), &bytes.Buffer{})
+
+	rows := []Row{{Rank: 1, Rating: 10, Detail: "ID: u1; Commits: 10;"}}
+	if err := action.AppendSummaryTable("Top users", rows); err != nil {
+		t.Fatalf("AppendSummaryTable: %v", err)
+	}
+
+	raw, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("reading summary: %v", err)
+	}
+	if !strings.Contains(string(raw), "### Top users") {
+		t.Errorf("summary missing title, got:\n%s", raw)
+	}
+	if !strings.Contains(string(raw), "ID: u1; Commits: 10;") {
+		t.Errorf("summary missing row detail, got:\n%s", raw)
+	}
+}
+
+func TestAction_SetOutput(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.env")
+	action := newAction(fakeEnv(map[string]string{"GITHUB_OUTPUT": outputPath}), &bytes.Buffer{})
+
+	if err := action.SetOutput("top_user_id", "u1"); err != nil {
+		t.Fatalf("SetOutput: %v", err)
+	}
+
+	raw, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !strings.Contains(string(raw), "top_user_id<<") || !strings.Contains(string(raw), "\nu1\n") {
+		t.Errorf("output missing heredoc value, got:\n%s", raw)
+	}
+}
+
+func TestAction_SetJSONOutput(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "output.env")
+	action := newAction(fakeEnv(map[string]string{"GITHUB_OUTPUT": outputPath}), &bytes.Buffer{})
+
+	if err := action.SetJSONOutput("ratings_json", map[string]int{"a": 1}); err != nil {
+		t.Fatalf("SetJSONOutput: %v", err)
+	}
+
+	raw, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !strings.Contains(string(raw), `{"a":1}`) {
+		t.Errorf("output missing JSON value, got:\n%s", raw)
+	}
+}
+
+func TestAction_EmitError(t *testing.T) {
+	var errOut bytes.Buffer
+	action := newAction(fakeEnv(nil), &errOut)
+
+	action.EmitError(errBoom)
+
+	if got := errOut.String(); got != "::error::boom\n" {
+		t.Errorf("EmitError wrote %q", got)
+	}
+}
+
+type staticError string
+
+func (e staticError) Error() string { return string(e) }
+
+const errBoom = staticError("boom")
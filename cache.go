@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/Korbiwe/adjust-test-task/rating"
+)
+
+// ratingCacheVersion is bumped whenever the rating algorithm or its on-disk
+// representation changes, so stale cache entries from an older binary are
+// never mistaken for fresh ones.
+const ratingCacheVersion = "1"
+
+const (
+	defaultCacheDirName = ".adjust-test-task-cache"
+	archiveCacheSubdir  = "archives"
+	ratingCacheSubdir   = "ratings"
+)
+
+// defaultCacheDir resolves to ~/.adjust-test-task-cache, falling back to a
+// relative directory if the home directory can't be determined.
+func defaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return defaultCacheDirName
+	}
+	return filepath.Join(home, defaultCacheDirName)
+}
+
+// archiveCacheKey hashes the archive URL together with its validator headers
+// (ETag and Last-Modified), so a re-published archive at the same URL busts
+// the cache instead of silently reusing stale data.
+func archiveCacheKey(url, etag, lastModified string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(url))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(etag))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(lastModified))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// probeArchiveValidators issues a HEAD request to read the archive's ETag
+// and Last-Modified headers without downloading the (potentially huge) body,
+// so the cache can be consulted before committing to a download.
+func probeArchiveValidators(ctx context.Context, url string) (etag string, lastModified string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+		// TODO: log error
+	}()
+
+	return resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+}
+
+// promoteToCacheDir moves src to dst, preferring a plain rename. src is
+// typically an OS temp dir (e.g. under /tmp) while dst lives under
+// --cache-dir, so the two are often on different filesystems/mounts
+// (tmpfs containers, a custom $TMPDIR); os.Rename fails with EXDEV there, so
+// it falls back to a recursive copy followed by removing src.
+func promoteToCacheDir(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyDir recursively copies src into dst, which must not already exist.
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+		// TODO: log error
+	}()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+		// TODO: log error
+	}()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func (a *App) archiveCacheDir(key string) string {
+	return filepath.Join(a.cacheDir, archiveCacheSubdir, key)
+}
+
+func (a *App) ratingCachePath(archiveHash string) string {
+	return filepath.Join(a.cacheDir, ratingCacheSubdir, archiveHash+"-"+ratingCacheVersion+".json")
+}
+
+// cachedRatings is the JSON-serializable form of the three top-N ratings
+// produced by PerformanceOptimizedRatings. The concrete types are used
+// directly (rather than boxed through rating.Ratable) since they only have
+// exported fields, so encoding/json needs no custom hooks.
+type cachedRatings struct {
+	Users       []*RatableUser       `json:"users"`
+	RepoCommits []*CommitRatableRepo `json:"repoCommits"`
+	RepoWatches []*WatchRatableRepo  `json:"repoWatches"`
+}
+
+func (a *App) loadCachedRatings(archiveHash string) (*rating.Rating[*RatableUser], *rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], bool, error) {
+	if archiveHash == "" {
+		return nil, nil, nil, false, nil
+	}
+
+	raw, err := ioutil.ReadFile(a.ratingCachePath(archiveHash))
+	if os.IsNotExist(err) {
+		return nil, nil, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	var cached cachedRatings
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	usersRating := rating.New[*RatableUser](len(cached.Users))
+	for _, user := range cached.Users {
+		usersRating.TryPush(user)
+	}
+
+	repoCommitsRating := rating.New[*CommitRatableRepo](len(cached.RepoCommits))
+	for _, repo := range cached.RepoCommits {
+		repoCommitsRating.TryPush(repo)
+	}
+
+	repoWatchesRating := rating.New[*WatchRatableRepo](len(cached.RepoWatches))
+	for _, repo := range cached.RepoWatches {
+		repoWatchesRating.TryPush(repo)
+	}
+
+	return usersRating, repoCommitsRating, repoWatchesRating, true, nil
+}
+
+func (a *App) saveCachedRatings(archiveHash string, usersRating *rating.Rating[*RatableUser], repoCommitsRating *rating.Rating[*CommitRatableRepo], repoWatchesRating *rating.Rating[*WatchRatableRepo]) error {
+	if archiveHash == "" {
+		return nil
+	}
+
+	cached := cachedRatings{
+		Users:       usersRating.Items(),
+		RepoCommits: repoCommitsRating.Items(),
+		RepoWatches: repoWatchesRating.Items(),
+	}
+
+	raw, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	path := a.ratingCachePath(archiveHash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, raw, 0644)
+}
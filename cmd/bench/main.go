@@ -0,0 +1,87 @@
+// Command bench compares a single-threaded event-processing run against the
+// sharded pipeline on the same archive. Since the rating logic lives in
+// package main at the repo root (not an importable library), this drives
+// the actual CLI binary with different --workers values rather than calling
+// internals directly - the same approach MinIO's scanner benchmarks take
+// when comparing disk-shuffled concurrency levels against a single worker.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// ratingsCacheSubdir must match cache.go's ratingCacheSubdir: between timed
+// runs it's wiped so each run actually recomputes ratings, while the
+// archive cache alongside it is left alone.
+const ratingsCacheSubdir = "ratings"
+
+// warmArchiveCache runs the CLI once against a scratch cache dir so the
+// archive is downloaded and extracted before any timing starts - otherwise
+// both timed runs would be dominated by network/extraction time rather than
+// the event-processing pipeline this benchmark is about.
+func warmArchiveCache(tarLink, cacheDir string) error {
+	cmd := exec.Command("go", "run", "..", "--tarLink", tarLink, "--cache-dir", cacheDir, "--silent", "--workers", "1")
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// timeRun times one CLI invocation against the pre-warmed archive cache.
+// The ratings cache is cleared first so the run can't just load a cached
+// result instead of actually processing events.
+func timeRun(tarLink, cacheDir string, workers int) (time.Duration, error) {
+	if err := os.RemoveAll(filepath.Join(cacheDir, ratingsCacheSubdir)); err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command("go", "run", "..", "--tarLink", tarLink, "--cache-dir", cacheDir, "--silent", "--workers", fmt.Sprint(workers))
+	cmd.Stderr = os.Stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	return time.Since(start), err
+}
+
+func main() {
+	tarLink := flag.String("tarLink", "", "tar link to download the data from (required)")
+	flag.Parse()
+
+	if *tarLink == "" {
+		fmt.Fprintln(os.Stderr, "bench: -tarLink is required")
+		os.Exit(1)
+	}
+
+	cacheDir, err := ioutil.TempDir("", "adjust-test-task-bench*")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(cacheDir)
+	}()
+
+	fmt.Println("warming the archive cache (download + extract, not timed)...")
+	if err := warmArchiveCache(*tarLink, cacheDir); err != nil {
+		panic(err)
+	}
+
+	singleThreaded, err := timeRun(*tarLink, cacheDir, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	workers := runtime.NumCPU()
+	sharded, err := timeRun(*tarLink, cacheDir, workers)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("single-threaded (--workers 1): %s\n", singleThreaded)
+	fmt.Printf("sharded (--workers %d):        %s\n", workers, sharded)
+	fmt.Printf("speedup: %.2fx\n", singleThreaded.Seconds()/sharded.Seconds())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/Korbiwe/adjust-test-task/actions"
+	"github.com/Korbiwe/adjust-test-task/rating"
+)
+
+// ratingRows converts a Rating's top-N values into the actions package's
+// plain Row type, so that package doesn't need to depend on rating.Ratable.
+func ratingRows[T rating.Ratable](r *rating.Rating[T]) []actions.Row {
+	items := r.Items()
+	rows := make([]actions.Row, 0, len(items))
+	for i, value := range items {
+		rows = append(rows, actions.Row{Rank: i + 1, Rating: value.GetRating(), Detail: value.Pretty()})
+	}
+	return rows
+}
+
+// topUserID returns the ID of the top-rated user, or "" if the rating is
+// empty.
+func topUserID(r *rating.Rating[*RatableUser]) string {
+	items := r.Items()
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0].ID
+}
+
+// topCommitRepoID returns the ID of the top-rated repo by commits, or "" if
+// the rating is empty.
+func topCommitRepoID(r *rating.Rating[*CommitRatableRepo]) string {
+	items := r.Items()
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0].ID
+}
+
+// topWatchRepoID returns the ID of the top-rated repo by watches, or "" if
+// the rating is empty.
+func topWatchRepoID(r *rating.Rating[*WatchRatableRepo]) string {
+	items := r.Items()
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0].ID
+}
+
+// reportToGitHubActions writes the three ratings to the GitHub Actions step
+// summary and step outputs, so a scheduled workflow can surface them on the
+// run and downstream steps can consume them without parsing stdout.
+func reportToGitHubActions(act *actions.Action, usersRating *rating.Rating[*RatableUser], repoCommitsRating *rating.Rating[*CommitRatableRepo], repoWatchesRating *rating.Rating[*WatchRatableRepo]) error {
+	if err := act.AppendSummaryTable("Top users", ratingRows(usersRating)); err != nil {
+		return err
+	}
+	if err := act.AppendSummaryTable("Top repos by commits", ratingRows(repoCommitsRating)); err != nil {
+		return err
+	}
+	if err := act.AppendSummaryTable("Top repos by watches", ratingRows(repoWatchesRating)); err != nil {
+		return err
+	}
+
+	if err := act.SetOutput("top_user_id", topUserID(usersRating)); err != nil {
+		return err
+	}
+	if err := act.SetOutput("top_repo_by_commits_id", topCommitRepoID(repoCommitsRating)); err != nil {
+		return err
+	}
+	if err := act.SetOutput("top_repo_by_watches_id", topWatchRepoID(repoWatchesRating)); err != nil {
+		return err
+	}
+
+	ratingsJSON := map[string]interface{}{
+		"users":       usersRating.Items(),
+		"repoCommits": repoCommitsRating.Items(),
+		"repoWatches": repoWatchesRating.Items(),
+	}
+	return act.SetJSONOutput("ratings_json", ratingsJSON)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"archive/tar"
+	"bufio"
 	"compress/gzip"
+	"context"
 	"encoding/csv"
 	"errors"
 	"flag"
@@ -11,8 +13,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/Korbiwe/adjust-test-task/actions"
+	"github.com/Korbiwe/adjust-test-task/progress"
+	"github.com/Korbiwe/adjust-test-task/rating"
 )
 
 const (
@@ -151,9 +161,54 @@ type RepoWithCommitsAndWatches struct {
 
 type App struct {
 	tempDir string
-}
-
-func extractTar(gzipStream io.Reader, path string) error {
+	// cacheDir, when set, is consulted by DownloadArchive and
+	// PerformanceOptimizedRatings to avoid re-downloading the archive and
+	// re-scanning the CSVs on successive runs.
+	cacheDir string
+	// archiveHash identifies the archive served into tempDir, used to key
+	// the rating cache. Empty when caching is disabled.
+	archiveHash string
+	// fromCache marks that tempDir points into the archive cache rather
+	// than a scratch temp dir, so Cleanup doesn't delete cached data.
+	fromCache bool
+	// silent discards all info output; noProgress keeps it but drops the
+	// progress bars. Both default to false (verbose, with bars).
+	silent     bool
+	noProgress bool
+	bars       *progress.Bars
+	// barsOnce guards the lazy init of bars: bar() is called from both the
+	// main goroutine and the SIGINT/SIGTERM handler goroutine, and a plain
+	// nil check there is a data race.
+	barsOnce sync.Once
+	// workers is the number of goroutines PerformanceOptimizedRatings
+	// shards event processing across. Defaults to runtime.NumCPU() in main.
+	workers int
+}
+
+// logf prints an info line, unless the app is running silent.
+func (a *App) logf(format string, args ...interface{}) {
+	if a.silent {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// logln is the Println counterpart of logf.
+func (a *App) logln(args ...interface{}) {
+	if a.silent {
+		return
+	}
+	fmt.Println(args...)
+}
+
+func (a *App) bar() *progress.Bars {
+	a.barsOnce.Do(func() {
+		a.bars = progress.New(!a.noProgress && !a.silent)
+	})
+	return a.bars
+}
+
+func extractTar(ctx context.Context, gzipStream io.Reader, path string, counter *progress.Counter) error {
 	// mitigating a path traversal vulnerability falls out of the test task scope.
 	// let's assume we trust the data, as nothing contrary to this was specified in the task description.
 	uncompressed, err := gzip.NewReader(gzipStream)
@@ -163,6 +218,10 @@ func extractTar(gzipStream io.Reader, path string) error {
 
 	tarReader := tar.NewReader(uncompressed)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
@@ -196,23 +255,48 @@ func extractTar(gzipStream io.Reader, path string) error {
 			if err != nil {
 				return err
 			}
+			counter.Increment()
 		default:
 			return ErrUnhandledHeaderTypeInArchive
 		}
 	}
+	counter.Finish()
 
 	return nil
 }
 
-func (a *App) DownloadArchive(url string) error {
-	resp, err := http.Get(url)
+func (a *App) DownloadArchive(ctx context.Context, url string) error {
+	if a.cacheDir != "" {
+		etag, lastModified, err := probeArchiveValidators(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		key := archiveCacheKey(url, etag, lastModified)
+		cacheDir := a.archiveCacheDir(key)
+		if info, err := os.Stat(cacheDir); err == nil && info.IsDir() {
+			a.tempDir = cacheDir
+			a.archiveHash = key
+			a.fromCache = true
+			a.logln("Using cached archive, skipping the download.")
+			return nil
+		}
+
+		a.archiveHash = key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
 	defer func() {
 		_ = resp.Body.Close()
 		// TODO: log err
 	}()
-	if err != nil {
-		return err
-	}
 	if resp.StatusCode != http.StatusOK {
 		return ErrCantDownloadArchive
 	}
@@ -223,10 +307,24 @@ func (a *App) DownloadArchive(url string) error {
 	}
 	a.tempDir = tempDir
 
-	if err = extractTar(resp.Body, a.tempDir); err != nil {
+	body := a.bar().WrapReader(resp.Body, resp.ContentLength, "Downloading")
+	counter := a.bar().NewCounter(0, "Extracting")
+	if err = extractTar(ctx, body, a.tempDir, counter); err != nil {
 		return err
 	}
 
+	if a.cacheDir != "" {
+		cacheDir := a.archiveCacheDir(a.archiveHash)
+		if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+			return err
+		}
+		if err := promoteToCacheDir(a.tempDir, cacheDir); err != nil {
+			return err
+		}
+		a.tempDir = cacheDir
+		a.fromCache = true
+	}
+
 	return nil
 }
 
@@ -386,10 +484,10 @@ func (a *App) countRepoRating(repoID string) (int64, int64, error) {
 	return commitsPushed, watchEvents, nil
 }
 
-func (a *App) rateReposByCommitsAndWatchesSpaceOptimized() (*Rating, *Rating, error) {
+func (a *App) rateReposByCommitsAndWatchesSpaceOptimized() (*rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], error) {
 	// first rating is by commits pushed, second is by watch events
-	commitsRating := NewRating(10)
-	watchRating := NewRating(10)
+	commitsRating := rating.New[*CommitRatableRepo](10)
+	watchRating := rating.New[*WatchRatableRepo](10)
 
 	reposFile, err := os.Open(filepath.Join(a.tempDir, "data", reposFilename))
 	defer func() {
@@ -421,14 +519,14 @@ func (a *App) rateReposByCommitsAndWatchesSpaceOptimized() (*Rating, *Rating, er
 			return nil, nil, err
 		}
 
-		fmt.Printf("Rating repo %s (ID: %s)... \n", repo.Name, repo.ID)
+		a.logf("Rating repo %s (ID: %s)... \n", repo.Name, repo.ID)
 
 		commitsCount, watchCount, err := a.countRepoRating(repo.ID)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		fmt.Printf("Repo %s (ID: %s) rated; Commits: %d; Watches: %d; \n\n", repo.Name, repo.ID, commitsCount, watchCount)
+		a.logf("Repo %s (ID: %s) rated; Commits: %d; Watches: %d; \n\n", repo.Name, repo.ID, commitsCount, watchCount)
 
 		commitsRating.TryPush(&CommitRatableRepo{ID: repo.ID, Name: repo.Name, Commits: commitsCount})
 		watchRating.TryPush(&WatchRatableRepo{ID: repo.ID, Name: repo.Name, WatchEvents: watchCount})
@@ -437,8 +535,8 @@ func (a *App) rateReposByCommitsAndWatchesSpaceOptimized() (*Rating, *Rating, er
 	return commitsRating, watchRating, nil
 }
 
-func (a *App) rateUsersByPRsAndCommitsSpaceOptimized() (*Rating, error) {
-	rating := NewRating(10)
+func (a *App) rateUsersByPRsAndCommitsSpaceOptimized() (*rating.Rating[*RatableUser], error) {
+	usersRating := rating.New[*RatableUser](10)
 	usersFile, err := os.Open(filepath.Join(a.tempDir, "data", actorsFilename))
 	defer func() {
 		_ = usersFile.Close()
@@ -471,22 +569,22 @@ func (a *App) rateUsersByPRsAndCommitsSpaceOptimized() (*Rating, error) {
 			return nil, err
 		}
 
-		fmt.Printf("Rating user %s (ID: %s)...\n", user.Username, user.ID)
+		a.logf("Rating user %s (ID: %s)...\n", user.Username, user.ID)
 
 		commitCount, prCount, err := a.countUserRating(user.ID)
 		if err != nil {
 			return nil, err
 		}
 
-		fmt.Printf("Finished rating user %s (ID: %s); Commits: %d; PR events: %d; \n\n", user.Username, user.ID, commitCount, prCount)
+		a.logf("Finished rating user %s (ID: %s); Commits: %d; PR events: %d; \n\n", user.Username, user.ID, commitCount, prCount)
 
-		rating.TryPush(&RatableUser{ID: user.ID, Username: user.Username, Commits: commitCount, PREvents: prCount})
+		usersRating.TryPush(&RatableUser{ID: user.ID, Username: user.Username, Commits: commitCount, PREvents: prCount})
 	}
 
-	return rating, nil
+	return usersRating, nil
 }
 
-func (a *App) SpaceOptimizedRatings() (*Rating, *Rating, *Rating, error) {
+func (a *App) SpaceOptimizedRatings() (*rating.Rating[*RatableUser], *rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], error) {
 	usersRating, err := a.rateUsersByPRsAndCommitsSpaceOptimized()
 	if err != nil {
 		return nil, nil, nil, err
@@ -584,83 +682,64 @@ func (a *App) fillRepoNames(repos map[string]*RepoWithCommitsAndWatches) error {
 	return nil
 }
 
-func (a *App) PerformanceOptimizedRatings() (*Rating, *Rating, *Rating, error) {
-	fmt.Println("Starting a performance optimized rating...")
-	users := make(map[string]*RatableUser)
-	repos := make(map[string]*RepoWithCommitsAndWatches)
-
-	usersRating := NewRating(10)
-	repoCommitsRating := NewRating(10)
-	repoWatchesRating := NewRating(10)
-
-	eventsFile, err := os.Open(filepath.Join(a.tempDir, "data", eventsFilename))
+// countLines seeds the event-processing progress bar's total: it's cheaper
+// to scan the file once for newlines than to load it fully into memory.
+func countLines(path string) (int64, error) {
+	file, err := os.Open(path)
 	defer func() {
-		_ = eventsFile.Close()
+		_ = file.Close()
 		// TODO: log errors
 	}()
 	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		count++
+	}
+
+	return count, scanner.Err()
+}
+
+func (a *App) PerformanceOptimizedRatings(ctx context.Context) (*rating.Rating[*RatableUser], *rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], error) {
+	if usersRating, repoCommitsRating, repoWatchesRating, ok, err := a.loadCachedRatings(a.archiveHash); err != nil {
 		return nil, nil, nil, err
+	} else if ok {
+		a.logln("Loaded ratings from cache, skipping the scan.")
+		return usersRating, repoCommitsRating, repoWatchesRating, nil
 	}
 
-	eventsReader := csv.NewReader(eventsFile)
-	wasHeaderRead := false
-	for {
-		record, err := eventsReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, nil, nil, err
-		}
+	a.logln("Starting a performance optimized rating...")
 
-		// skip the header
-		if !wasHeaderRead {
-			wasHeaderRead = true
-			continue
-		}
+	usersRating := rating.New[*RatableUser](10)
+	repoCommitsRating := rating.New[*CommitRatableRepo](10)
+	repoWatchesRating := rating.New[*WatchRatableRepo](10)
 
-		event, err := NewEventFromCSV(record)
-		fmt.Printf("Processing event %s...\n", event.ID)
+	workers := a.workers
+	if workers < 1 {
+		workers = 1
+	}
 
-		switch event.Type {
-		case eventTypePush:
-			commitsCount, err := a.countCommitsByEvent(event.ID)
-			if err != nil {
-				return nil, nil, nil, err
-			}
-			user, ok := users[event.ActorID]
-			if !ok {
-				users[event.ActorID] = &RatableUser{ID: event.ActorID, Commits: commitsCount}
-			} else {
-				user.Commits += commitsCount
-			}
+	commitCounts, err := buildCommitCounts(filepath.Join(a.tempDir, "data", commitsFilename))
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-			repo, ok := repos[event.RepoID]
-			if !ok {
-				repos[event.RepoID] = &RepoWithCommitsAndWatches{ID: event.RepoID, Commits: commitsCount}
-			} else {
-				repo.Commits += commitsCount
-			}
-		case eventTypePullRequest:
-			user, ok := users[event.ActorID]
-			if !ok {
-				users[event.ActorID] = &RatableUser{ID: event.ActorID, PREvents: 1}
-			} else {
-				user.PREvents += 1
-			}
-		case eventTypeWatch:
-			repo, ok := repos[event.RepoID]
-			if !ok {
-				repos[event.RepoID] = &RepoWithCommitsAndWatches{ID: event.RepoID, WatchEvents: 1}
-			} else {
-				repo.WatchEvents += 1
-			}
-		default:
-			continue
-		}
+	eventsPath := filepath.Join(a.tempDir, "data", eventsFilename)
+	eventCount, err := countLines(eventsPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	counter := a.bar().NewCounter(eventCount-1, "Processing events")
 
-		fmt.Printf("Event %s processed.\n\n", event.ID)
+	users, repos, err := a.processEventsConcurrently(ctx, workers, commitCounts, counter)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	counter.Finish()
 
 	err = a.fillUsernames(users)
 	if err != nil {
@@ -680,11 +759,15 @@ func (a *App) PerformanceOptimizedRatings() (*Rating, *Rating, *Rating, error) {
 		repoWatchesRating.TryPush(&WatchRatableRepo{ID: value.ID, Name: value.Name, WatchEvents: value.WatchEvents})
 	}
 
+	if err := a.saveCachedRatings(a.archiveHash, usersRating, repoCommitsRating, repoWatchesRating); err != nil {
+		return nil, nil, nil, err
+	}
+
 	return usersRating, repoCommitsRating, repoWatchesRating, nil
 }
 
 func (a *App) Cleanup() error {
-	if a.tempDir != "" {
+	if a.tempDir != "" && !a.fromCache {
 		if err := os.RemoveAll(a.tempDir); err != nil {
 			return err
 		}
@@ -694,21 +777,81 @@ func (a *App) Cleanup() error {
 
 func main() {
 	tarLink := flag.String("tarLink", defaultTarLink, "tar link to download the data from")
+	cacheDir := flag.String("cache-dir", defaultCacheDir(), "directory used to cache the downloaded archive and computed ratings; pass an empty string to disable caching")
+	silent := flag.Bool("silent", false, "discard all info output")
+	noProgress := flag.Bool("no-progress", false, "keep info output, but drop the progress bars")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of goroutines to shard event processing across")
+	stream := flag.Bool("stream", false, "never stage the archive or CSVs to disk; process everything from the HTTP response as it arrives")
+	githubActions := flag.Bool("github-actions", os.Getenv("GITHUB_ACTIONS") == "true", "write ratings to the GitHub Actions step summary and outputs")
 	flag.Parse()
-	app := &App{tempDir: tempDirName}
+	app := &App{tempDir: tempDirName, cacheDir: *cacheDir, silent: *silent, noProgress: *noProgress, workers: *workers}
 
-	if err := app.DownloadArchive(*tarLink); err != nil {
+	var act *actions.Action
+	if *githubActions {
+		act = actions.New()
+	}
+	fail := func(err error) {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "cancelled.")
+			os.Exit(1)
+		}
+		if act != nil {
+			act.EmitError(err)
+			os.Exit(1)
+		}
 		panic(err)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		app.bar().FinishAll()
+		cancel()
+	}()
+
+	if *stream {
+		poStartTime := time.Now()
+		usersRatingPO, repoCommitsRatingPO, repoWatchesRatingPO, err := app.StreamRatings(ctx, *tarLink)
+		if err != nil {
+			fail(err)
+			return
+		}
+		poTimeTaken := time.Since(poStartTime)
+
+		fmt.Printf("\nRatings: \n")
+		fmt.Printf("Users: \n%s \n", usersRatingPO.Pretty())
+		fmt.Printf("Repo commits: \n%s \n", repoCommitsRatingPO.Pretty())
+		fmt.Printf("Repo watches: \n%s \n", repoWatchesRatingPO.Pretty())
+
+		fmt.Printf("\nTimings: \n")
+		fmt.Printf("Stream: %s \n", poTimeTaken)
+
+		if act != nil {
+			if err := reportToGitHubActions(act, usersRatingPO, repoCommitsRatingPO, repoWatchesRatingPO); err != nil {
+				fail(err)
+				return
+			}
+		}
+		return
+	}
+
+	if err := app.DownloadArchive(ctx, *tarLink); err != nil {
+		_ = app.Cleanup()
+		fail(err)
+		return
+	}
 	defer func() {
 		_ = app.Cleanup()
 		// TODO: log error
 	}()
 
 	poStartTime := time.Now()
-	usersRatingPO, repoCommitsRatingPO, repoWatchesRatingPO, err := app.PerformanceOptimizedRatings()
+	usersRatingPO, repoCommitsRatingPO, repoWatchesRatingPO, err := app.PerformanceOptimizedRatings(ctx)
 	if err != nil {
-		panic(err)
+		fail(err)
+		return
 	}
 	poTimeTaken := time.Since(poStartTime)
 
@@ -719,4 +862,10 @@ func main() {
 
 	fmt.Printf("\nTimings: \n")
 	fmt.Printf("PO: %s \n", poTimeTaken)
+
+	if act != nil {
+		if err := reportToGitHubActions(act, usersRatingPO, repoCommitsRatingPO, repoWatchesRatingPO); err != nil {
+			fail(err)
+		}
+	}
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Korbiwe/adjust-test-task/progress"
+)
+
+// updateKind identifies which aggregate an eventUpdate contributes to.
+type updateKind int
+
+const (
+	updateUserCommit updateKind = iota
+	updateUserPR
+	updateRepoCommit
+	updateRepoWatch
+)
+
+// eventUpdate is a single partial aggregate dispatched to exactly one
+// worker's shard.
+type eventUpdate struct {
+	kind    updateKind
+	id      string // ActorID or RepoID, depending on kind
+	commits int64
+}
+
+// shard is one worker's private slice of the aggregate state. Events are
+// routed to a shard by hashing ActorID/RepoID, so two workers never touch
+// the same key and no locking is needed while a shard is being built.
+type shard struct {
+	users map[string]*RatableUser
+	repos map[string]*RepoWithCommitsAndWatches
+}
+
+func newShard() *shard {
+	return &shard{
+		users: make(map[string]*RatableUser),
+		repos: make(map[string]*RepoWithCommitsAndWatches),
+	}
+}
+
+func (s *shard) apply(update eventUpdate) {
+	switch update.kind {
+	case updateUserCommit:
+		user, ok := s.users[update.id]
+		if !ok {
+			s.users[update.id] = &RatableUser{ID: update.id, Commits: update.commits}
+		} else {
+			user.Commits += update.commits
+		}
+	case updateUserPR:
+		user, ok := s.users[update.id]
+		if !ok {
+			s.users[update.id] = &RatableUser{ID: update.id, PREvents: 1}
+		} else {
+			user.PREvents += 1
+		}
+	case updateRepoCommit:
+		repo, ok := s.repos[update.id]
+		if !ok {
+			s.repos[update.id] = &RepoWithCommitsAndWatches{ID: update.id, Commits: update.commits}
+		} else {
+			repo.Commits += update.commits
+		}
+	case updateRepoWatch:
+		repo, ok := s.repos[update.id]
+		if !ok {
+			s.repos[update.id] = &RepoWithCommitsAndWatches{ID: update.id, WatchEvents: 1}
+		} else {
+			repo.WatchEvents += 1
+		}
+	}
+}
+
+// shardIndex hashes id into one of shardCount buckets.
+func shardIndex(id string, shardCount int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() % uint32(shardCount))
+}
+
+// buildCommitCounts pre-scans commits.csv once, building a map[eventID]count
+// consulted in O(1) per event. This replaces re-opening and re-scanning the
+// entire commits CSV for every push event.
+func buildCommitCounts(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	defer func() {
+		_ = file.Close()
+		// TODO: log errors
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	reader := csv.NewReader(file)
+	wasHeaderRead := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// skip the header
+		if !wasHeaderRead {
+			wasHeaderRead = true
+			continue
+		}
+
+		commit, err := NewCommitFromCSV(record)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[commit.EventID]++
+	}
+
+	return counts, nil
+}
+
+// processEventsConcurrently reads events.csv on disk and dispatches each
+// record to one of workerCount worker goroutines, sharded by ActorID/RepoID.
+func (a *App) processEventsConcurrently(ctx context.Context, workerCount int, commitCounts map[string]int64, counter *progress.Counter) (map[string]*RatableUser, map[string]*RepoWithCommitsAndWatches, error) {
+	eventsFile, err := os.Open(filepath.Join(a.tempDir, "data", eventsFilename))
+	defer func() {
+		_ = eventsFile.Close()
+		// TODO: log errors
+	}()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventsReader := csv.NewReader(eventsFile)
+	wasHeaderRead := false
+	next := func() (*Event, error) {
+		for {
+			record, err := eventsReader.Read()
+			if err != nil {
+				return nil, err
+			}
+			if !wasHeaderRead {
+				wasHeaderRead = true
+				continue
+			}
+			return NewEventFromCSV(record)
+		}
+	}
+
+	return processEventStream(ctx, workerCount, commitCounts, counter, next)
+}
+
+// processEventStream dispatches events pulled from next (terminated by
+// io.EOF) to workerCount worker goroutines, sharded by ActorID/RepoID.
+// Workers only ever touch their own shard, so the hot path needs no
+// locking; shards are merged into plain maps once every worker has drained
+// its channel. Shared by the on-disk scan and the in-memory stream mode.
+func processEventStream(ctx context.Context, workerCount int, commitCounts map[string]int64, counter *progress.Counter, next func() (*Event, error)) (map[string]*RatableUser, map[string]*RepoWithCommitsAndWatches, error) {
+	shards := make([]*shard, workerCount)
+	channels := make([]chan eventUpdate, workerCount)
+	for i := range shards {
+		shards[i] = newShard()
+		channels[i] = make(chan eventUpdate, 1024)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workerCount)
+	for i := 0; i < workerCount; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for update := range channels[i] {
+				shards[i].apply(update)
+			}
+		}(i)
+	}
+
+	dispatch := func(id string, update eventUpdate) {
+		channels[shardIndex(id, workerCount)] <- update
+	}
+	abort := func() {
+		for _, ch := range channels {
+			close(ch)
+		}
+		wg.Wait()
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			abort()
+			return nil, nil, err
+		}
+
+		event, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			abort()
+			return nil, nil, err
+		}
+
+		switch event.Type {
+		case eventTypePush:
+			commitsCount := commitCounts[event.ID]
+			dispatch(event.ActorID, eventUpdate{kind: updateUserCommit, id: event.ActorID, commits: commitsCount})
+			dispatch(event.RepoID, eventUpdate{kind: updateRepoCommit, id: event.RepoID, commits: commitsCount})
+		case eventTypePullRequest:
+			dispatch(event.ActorID, eventUpdate{kind: updateUserPR, id: event.ActorID})
+		case eventTypeWatch:
+			dispatch(event.RepoID, eventUpdate{kind: updateRepoWatch, id: event.RepoID})
+		default:
+			counter.Increment()
+			continue
+		}
+
+		counter.Increment()
+	}
+
+	for _, ch := range channels {
+		close(ch)
+	}
+	wg.Wait()
+
+	users := make(map[string]*RatableUser)
+	repos := make(map[string]*RepoWithCommitsAndWatches)
+	for _, s := range shards {
+		for id, user := range s.users {
+			users[id] = user
+		}
+		for id, repo := range s.repos {
+			repos[id] = repo
+		}
+	}
+
+	return users, repos, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/Korbiwe/adjust-test-task/progress"
+)
+
+func syntheticEvents() []*Event {
+	return []*Event{
+		{ID: "e1", Type: eventTypePush, ActorID: "u1", RepoID: "r1"},
+		{ID: "e2", Type: eventTypePullRequest, ActorID: "u1", RepoID: "r1"},
+		{ID: "e3", Type: eventTypeWatch, ActorID: "u2", RepoID: "r1"},
+		{ID: "e4", Type: eventTypePush, ActorID: "u2", RepoID: "r2"},
+		{ID: "e5", Type: eventTypeFork, ActorID: "u3", RepoID: "r2"},
+		{ID: "e6", Type: eventTypePush, ActorID: "u1", RepoID: "r2"},
+		{ID: "e7", Type: eventTypePullRequest, ActorID: "u3", RepoID: "r3"},
+		{ID: "e8", Type: eventTypeWatch, ActorID: "u1", RepoID: "r3"},
+	}
+}
+
+func syntheticCommitCounts() map[string]int64 {
+	return map[string]int64{"e1": 3, "e4": 1, "e6": 5}
+}
+
+// naiveProcessEvents applies every event to a single shard in order, giving
+// the oracle processEventStream's sharded, concurrent result is checked
+// against.
+func naiveProcessEvents(events []*Event, commitCounts map[string]int64) (map[string]*RatableUser, map[string]*RepoWithCommitsAndWatches) {
+	s := newShard()
+	for _, event := range events {
+		switch event.Type {
+		case eventTypePush:
+			commits := commitCounts[event.ID]
+			s.apply(eventUpdate{kind: updateUserCommit, id: event.ActorID, commits: commits})
+			s.apply(eventUpdate{kind: updateRepoCommit, id: event.RepoID, commits: commits})
+		case eventTypePullRequest:
+			s.apply(eventUpdate{kind: updateUserPR, id: event.ActorID})
+		case eventTypeWatch:
+			s.apply(eventUpdate{kind: updateRepoWatch, id: event.RepoID})
+		}
+	}
+	return s.users, s.repos
+}
+
+// eventIterator adapts a slice into the next func() (*Event, error) shape
+// processEventStream expects, terminated by io.EOF.
+func eventIterator(events []*Event) func() (*Event, error) {
+	i := 0
+	return func() (*Event, error) {
+		if i >= len(events) {
+			return nil, io.EOF
+		}
+		e := events[i]
+		i++
+		return e, nil
+	}
+}
+
+func TestProcessEventStream_MatchesNaiveOracleAcrossWorkerCounts(t *testing.T) {
+	events := syntheticEvents()
+	commitCounts := syntheticCommitCounts()
+	wantUsers, wantRepos := naiveProcessEvents(events, commitCounts)
+
+	for _, workers := range []int{1, 2, 3, 8} {
+		workers := workers
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			counter := progress.New(false).NewCounter(int64(len(events)), "test")
+			gotUsers, gotRepos, err := processEventStream(context.Background(), workers, commitCounts, counter, eventIterator(events))
+			if err != nil {
+				t.Fatalf("processEventStream: %v", err)
+			}
+
+			if len(gotUsers) != len(wantUsers) {
+				t.Fatalf("users length = %d, want %d", len(gotUsers), len(wantUsers))
+			}
+			for id, want := range wantUsers {
+				got, ok := gotUsers[id]
+				if !ok {
+					t.Fatalf("missing user %s", id)
+				}
+				if got.Commits != want.Commits || got.PREvents != want.PREvents {
+					t.Errorf("user %s = %+v, want %+v", id, got, want)
+				}
+			}
+
+			if len(gotRepos) != len(wantRepos) {
+				t.Fatalf("repos length = %d, want %d", len(gotRepos), len(wantRepos))
+			}
+			for id, want := range wantRepos {
+				got, ok := gotRepos[id]
+				if !ok {
+					t.Fatalf("missing repo %s", id)
+				}
+				if got.Commits != want.Commits || got.WatchEvents != want.WatchEvents {
+					t.Errorf("repo %s = %+v, want %+v", id, got, want)
+				}
+			}
+		})
+	}
+}
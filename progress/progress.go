@@ -0,0 +1,84 @@
+// Package progress wraps github.com/cheggaaa/pb/v3 into the handful of bars
+// this tool needs: a byte-progress bar for downloads, and item-count bars for
+// tar extraction and event processing. Every constructor is a no-op when the
+// bars are disabled, so callers don't need to branch on that themselves.
+package progress
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+// Bars creates the progress bars for a single run and keeps track of the
+// ones currently active, so they can all be finished at once on a SIGINT.
+// Bars started on the main goroutine and finished from the signal handler
+// goroutine, so active is guarded by mu.
+type Bars struct {
+	enabled bool
+	mu      sync.Mutex
+	active  []*pb.ProgressBar
+}
+
+func New(enabled bool) *Bars {
+	return &Bars{enabled: enabled}
+}
+
+func (b *Bars) track(bar *pb.ProgressBar) *pb.ProgressBar {
+	b.mu.Lock()
+	b.active = append(b.active, bar)
+	b.mu.Unlock()
+	return bar
+}
+
+// WrapReader tees r through a byte-progress bar sized to total. It returns r
+// unchanged when bars are disabled or total is unknown (<= 0).
+func (b *Bars) WrapReader(r io.Reader, total int64, prefix string) io.Reader {
+	if !b.enabled || total <= 0 {
+		return r
+	}
+	bar := b.track(pb.Full.Start64(total))
+	bar.Set("prefix", prefix+" ")
+	return bar.NewProxyReader(r)
+}
+
+// Counter is an item-count progress bar, used where progress is measured in
+// files or events rather than bytes.
+type Counter struct {
+	bar *pb.ProgressBar
+}
+
+func (b *Bars) NewCounter(total int64, prefix string) *Counter {
+	if !b.enabled {
+		return &Counter{}
+	}
+	bar := b.track(pb.Full.Start64(total))
+	bar.Set("prefix", prefix+" ")
+	return &Counter{bar: bar}
+}
+
+func (c *Counter) Increment() {
+	if c.bar != nil {
+		c.bar.Increment()
+	}
+}
+
+func (c *Counter) Finish() {
+	if c.bar != nil {
+		c.bar.Finish()
+	}
+}
+
+// FinishAll finishes every bar started so far, so a cancelled run leaves the
+// terminal in a clean state instead of a bar frozen mid-progress.
+func (b *Bars) FinishAll() {
+	b.mu.Lock()
+	active := make([]*pb.ProgressBar, len(b.active))
+	copy(active, b.active)
+	b.mu.Unlock()
+
+	for _, bar := range active {
+		bar.Finish()
+	}
+}
@@ -0,0 +1,134 @@
+// Package rating implements a bounded top-N rating: it keeps only the
+// highest-rated values seen, in O(log k) per candidate instead of the
+// O(k) scan-and-shift a plain sorted slice needs.
+package rating
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// Ratable is anything a Rating can rank.
+type Ratable interface {
+	GetRating() float64
+	Pretty() string
+}
+
+// entry pairs a value with its insertion order, so ties can be broken
+// stably (earlier insertions outrank later ones, matching what a
+// scan-and-shift by descending rating would have done).
+type entry[T Ratable] struct {
+	value T
+	seq   int64
+}
+
+// heapData is the container/heap.Interface implementation backing Rating.
+// It's unexported so Rating's public surface stays just
+// TryPush/Len/Items/Pretty, not heap internals.
+type heapData[T Ratable] []entry[T]
+
+func (h heapData[T]) Len() int { return len(h) }
+
+// Less ranks by rating first; among ties it ranks the later-inserted entry
+// lower, so it sits at the heap's root and is the one TryPush evicts,
+// leaving earlier insertions in place as documented on entry.
+func (h heapData[T]) Less(i, j int) bool {
+	ri, rj := h[i].value.GetRating(), h[j].value.GetRating()
+	if ri != rj {
+		return ri < rj
+	}
+	return h[i].seq > h[j].seq
+}
+
+func (h heapData[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *heapData[T]) Push(x interface{}) {
+	*h = append(*h, x.(entry[T]))
+}
+
+func (h *heapData[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// Rating is a bounded min-heap of size k: it keeps the k highest-rated
+// values TryPush has seen. Peeking the minimum (the eviction candidate) is
+// O(1); replacing it is O(log k).
+type Rating[T Ratable] struct {
+	data heapData[T]
+	size int
+	seq  int64
+}
+
+func New[T Ratable](size int) *Rating[T] {
+	return &Rating[T]{
+		data: make(heapData[T], 0, size),
+		size: size,
+	}
+}
+
+// TryPush offers value to the rating. If there's still room, value is
+// inserted. If the rating is full, value replaces the current minimum only
+// when it rates strictly higher; ties keep whichever value arrived first.
+// TryPush reports whether value was kept.
+func (r *Rating[T]) TryPush(value T) bool {
+	if r.size <= 0 {
+		return false
+	}
+
+	e := entry[T]{value: value, seq: r.seq}
+	r.seq++
+
+	if r.data.Len() < r.size {
+		heap.Push(&r.data, e)
+		return true
+	}
+
+	if e.value.GetRating() <= r.data[0].value.GetRating() {
+		return false
+	}
+
+	r.data[0] = e
+	heap.Fix(&r.data, 0)
+	return true
+}
+
+// Len reports how many values the rating currently holds.
+func (r *Rating[T]) Len() int {
+	return r.data.Len()
+}
+
+// Items returns the rating's current values sorted by rating descending,
+// ties broken by insertion order. It doesn't mutate the rating.
+func (r *Rating[T]) Items() []T {
+	entries := make([]entry[T], len(r.data))
+	copy(entries, r.data)
+
+	sort.Slice(entries, func(i, j int) bool {
+		ri, rj := entries[i].value.GetRating(), entries[j].value.GetRating()
+		if ri != rj {
+			return ri > rj
+		}
+		return entries[i].seq < entries[j].seq
+	})
+
+	items := make([]T, len(entries))
+	for i, e := range entries {
+		items[i] = e.value
+	}
+	return items
+}
+
+// Pretty renders the rating's values in the same order Items returns them,
+// one per line, numbered starting at 1.
+func (r *Rating[T]) Pretty() string {
+	pretty := ""
+	for i, value := range r.Items() {
+		pretty += fmt.Sprintf("%d (Rating: %f): %s\n", i+1, value.GetRating(), value.Pretty())
+	}
+	return pretty
+}
@@ -0,0 +1,186 @@
+package rating
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type testItem struct {
+	rating float64
+	label  string
+}
+
+func (t testItem) GetRating() float64 { return t.rating }
+func (t testItem) Pretty() string     { return t.label }
+
+func TestRating_Empty(t *testing.T) {
+	r := New[testItem](3)
+
+	if got := r.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+	if got := r.Items(); len(got) != 0 {
+		t.Errorf("Items() = %v, want empty", got)
+	}
+	if got := r.Pretty(); got != "" {
+		t.Errorf("Pretty() = %q, want empty string", got)
+	}
+}
+
+func TestRating_PushesBelowAndAboveThreshold(t *testing.T) {
+	r := New[testItem](2)
+
+	if ok := r.TryPush(testItem{rating: 1, label: "a"}); !ok {
+		t.Fatalf("TryPush(a) = false, want true (room available)")
+	}
+	if ok := r.TryPush(testItem{rating: 2, label: "b"}); !ok {
+		t.Fatalf("TryPush(b) = false, want true (room available)")
+	}
+
+	// rating 0 doesn't beat the current minimum (1) - rejected.
+	if ok := r.TryPush(testItem{rating: 0, label: "c"}); ok {
+		t.Fatalf("TryPush(c) = true, want false (below threshold)")
+	}
+
+	// rating 3 beats the current minimum (1) - accepted, evicting a.
+	if ok := r.TryPush(testItem{rating: 3, label: "d"}); !ok {
+		t.Fatalf("TryPush(d) = false, want true (above threshold)")
+	}
+
+	got := r.Items()
+	want := []testItem{{rating: 3, label: "d"}, {rating: 2, label: "b"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Items() = %v, want %v", got, want)
+	}
+}
+
+func TestRating_FullHeapEqualRatings_StableOrder(t *testing.T) {
+	r := New[testItem](3)
+
+	for _, label := range []string{"a", "b", "c", "d"} {
+		r.TryPush(testItem{rating: 5, label: label})
+	}
+
+	// d arrived after the rating was already full and didn't beat the
+	// (tied) minimum, so it's rejected; a, b, c keep their insertion order.
+	got := r.Items()
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("Items() length = %d, want %d", len(got), len(want))
+	}
+	for i, label := range want {
+		if got[i].label != label {
+			t.Errorf("Items()[%d].label = %q, want %q", i, got[i].label, label)
+		}
+	}
+}
+
+func TestRating_TieEvictionRespectsInsertionOrder(t *testing.T) {
+	r := New[testItem](2)
+
+	r.TryPush(testItem{rating: 5, label: "a"})
+	r.TryPush(testItem{rating: 5, label: "b"})
+
+	// Both a and b are tied at 5; a arrived first, so a higher-rated
+	// newcomer must evict b, not a.
+	if ok := r.TryPush(testItem{rating: 6, label: "d"}); !ok {
+		t.Fatalf("TryPush(d) = false, want true (above threshold)")
+	}
+
+	got := r.Items()
+	want := []string{"d", "a"}
+	if len(got) != len(want) {
+		t.Fatalf("Items() length = %d, want %d", len(got), len(want))
+	}
+	for i, label := range want {
+		if got[i].label != label {
+			t.Errorf("Items()[%d].label = %q, want %q", i, got[i].label, label)
+		}
+	}
+}
+
+func TestRating_SizeZero(t *testing.T) {
+	r := New[testItem](0)
+
+	if ok := r.TryPush(testItem{rating: 1, label: "a"}); ok {
+		t.Errorf("TryPush on a zero-size rating = true, want false")
+	}
+	if got := r.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+}
+
+// naiveTopK sorts all items by rating descending, ties broken by original
+// insertion index, then truncates to k - the oracle TryPush must match.
+func naiveTopK(items []testItem, k int) []testItem {
+	type indexed struct {
+		item testItem
+		idx  int
+	}
+
+	all := make([]indexed, len(items))
+	for i, item := range items {
+		all[i] = indexed{item: item, idx: i}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].item.rating != all[j].item.rating {
+			return all[i].item.rating > all[j].item.rating
+		}
+		return all[i].idx < all[j].idx
+	})
+
+	if len(all) > k {
+		all = all[:k]
+	}
+
+	result := make([]testItem, len(all))
+	for i, e := range all {
+		result[i] = e.item
+	}
+	return result
+}
+
+func FuzzRating_MatchesNaiveTopK(f *testing.F) {
+	f.Add(int64(1), 5, 2)
+	f.Add(int64(42), 0, 3)
+	f.Add(int64(7), 20, 1)
+	f.Add(int64(99), 50, 10)
+
+	f.Fuzz(func(t *testing.T, seed int64, n, k int) {
+		if n < 0 || n > 200 {
+			t.Skip()
+		}
+		if k <= 0 || k > 50 {
+			t.Skip()
+		}
+
+		rnd := rand.New(rand.NewSource(seed))
+		items := make([]testItem, n)
+		for i := range items {
+			// Small integer range, not rnd.Float64()*100: real ratings are
+			// integer counts (commits, PR events, ...), so exact ties are
+			// common in production and need to be exercised here too.
+			items[i] = testItem{rating: float64(rnd.Intn(5)), label: fmt.Sprintf("item-%d", i)}
+		}
+
+		r := New[testItem](k)
+		for _, item := range items {
+			r.TryPush(item)
+		}
+
+		got := r.Items()
+		want := naiveTopK(items, k)
+
+		if len(got) != len(want) {
+			t.Fatalf("length mismatch: got %d, want %d", len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("item %d mismatch: got %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Korbiwe/adjust-test-task/rating"
+)
+
+// StreamRatings computes ratings straight from the HTTP response without
+// ever writing the archive or its extracted CSVs to disk - useful for
+// containerized runs where the ephemeral disk is small or read-only. Events,
+// commits, actors and repos can arrive in any order inside the tarball, so
+// each tar entry is buffered in memory until all four have been observed,
+// then ratings are finalized in one pass.
+func (a *App) StreamRatings(ctx context.Context, url string) (*rating.Rating[*RatableUser], *rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+		// TODO: log err
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, nil, ErrCantDownloadArchive
+	}
+
+	// Hand the (progress-wrapped) HTTP body into the gzip/tar readers
+	// through a pipe, mirroring how etcd's Maintenance.Snapshot streams a
+	// server-side snapshot through io.Pipe without staging it to disk.
+	pr, pw := io.Pipe()
+	go func() {
+		body := a.bar().WrapReader(resp.Body, resp.ContentLength, "Streaming")
+		_, err := io.Copy(pw, body)
+		_ = pw.CloseWithError(err)
+	}()
+
+	return a.ratingsFromTarStream(ctx, pr)
+}
+
+// streamBuffers accumulates the four CSV streams found inside the tarball
+// until every one of them has been seen, since tar doesn't guarantee any
+// particular entry order.
+type streamBuffers struct {
+	events       []*Event
+	commitCounts map[string]int64
+	usernames    map[string]string
+	repoNames    map[string]string
+	seen         map[string]bool
+}
+
+func newStreamBuffers() *streamBuffers {
+	return &streamBuffers{
+		commitCounts: make(map[string]int64),
+		usernames:    make(map[string]string),
+		repoNames:    make(map[string]string),
+		seen:         make(map[string]bool),
+	}
+}
+
+func (b *streamBuffers) ready() bool {
+	return b.seen[eventsFilename] && b.seen[commitsFilename] && b.seen[actorsFilename] && b.seen[reposFilename]
+}
+
+// consume reads one tar entry's CSV records into the buffer matching its
+// filename.
+func (b *streamBuffers) consume(name string, r io.Reader) error {
+	reader := csv.NewReader(r)
+	wasHeaderRead := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// skip the header
+		if !wasHeaderRead {
+			wasHeaderRead = true
+			continue
+		}
+
+		switch name {
+		case eventsFilename:
+			event, err := NewEventFromCSV(record)
+			if err != nil {
+				return err
+			}
+			b.events = append(b.events, event)
+		case commitsFilename:
+			commit, err := NewCommitFromCSV(record)
+			if err != nil {
+				return err
+			}
+			b.commitCounts[commit.EventID]++
+		case actorsFilename:
+			user, err := NewUserFromCSV(record)
+			if err != nil {
+				return err
+			}
+			b.usernames[user.ID] = user.Username
+		case reposFilename:
+			repo, err := NewRepoFromCSV(record)
+			if err != nil {
+				return err
+			}
+			b.repoNames[repo.ID] = repo.Name
+		}
+	}
+
+	b.seen[name] = true
+	return nil
+}
+
+// ratingsFromTarStream decompresses and untars r entry by entry, buffers
+// the four CSVs it finds, then feeds the buffered events into the same
+// sharded pipeline used by PerformanceOptimizedRatings.
+func (a *App) ratingsFromTarStream(ctx context.Context, r io.Reader) (*rating.Rating[*RatableUser], *rating.Rating[*CommitRatableRepo], *rating.Rating[*WatchRatableRepo], error) {
+	uncompressed, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tarReader := tar.NewReader(uncompressed)
+	buffers := newStreamBuffers()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := buffers.consume(filepath.Base(header.Name), tarReader); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if !buffers.ready() {
+		return nil, nil, nil, fmt.Errorf("stream ratings: archive is missing one of %s, %s, %s, %s", eventsFilename, commitsFilename, actorsFilename, reposFilename)
+	}
+
+	workers := a.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	index := 0
+	next := func() (*Event, error) {
+		if index >= len(buffers.events) {
+			return nil, io.EOF
+		}
+		event := buffers.events[index]
+		index++
+		return event, nil
+	}
+
+	counter := a.bar().NewCounter(int64(len(buffers.events)), "Processing events")
+	users, repos, err := processEventStream(ctx, workers, buffers.commitCounts, counter, next)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	counter.Finish()
+
+	usersRating := rating.New[*RatableUser](10)
+	repoCommitsRating := rating.New[*CommitRatableRepo](10)
+	repoWatchesRating := rating.New[*WatchRatableRepo](10)
+
+	for _, user := range users {
+		if username, ok := buffers.usernames[user.ID]; ok {
+			user.Username = username
+		}
+		usersRating.TryPush(user)
+	}
+
+	for _, repo := range repos {
+		name := buffers.repoNames[repo.ID]
+		repoCommitsRating.TryPush(&CommitRatableRepo{ID: repo.ID, Name: name, Commits: repo.Commits})
+		repoWatchesRating.TryPush(&WatchRatableRepo{ID: repo.ID, Name: name, WatchEvents: repo.WatchEvents})
+	}
+
+	return usersRating, repoCommitsRating, repoWatchesRating, nil
+}
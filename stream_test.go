@@ -0,0 +1,175 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strconv"
+	"testing"
+)
+
+var (
+	streamTestUsernames = map[string]string{"u1": "user-one", "u2": "user-two", "u3": "user-three"}
+	streamTestRepoNames = map[string]string{"r1": "repo-one", "r2": "repo-two", "r3": "repo-three"}
+)
+
+// actorsCSV, commitsCSV, eventsCSV and reposCSV build the four CSV bodies
+// ratingsFromTarStream expects to find inside the tarball. eventsCSV and
+// commitsCSV render the synthetic events/commitCounts pipeline_test.go
+// already uses as its oracle input; actorsCSV and reposCSV render
+// streamTestUsernames and streamTestRepoNames.
+func actorsCSV() string {
+	csv := "id,username\n"
+	for id, name := range streamTestUsernames {
+		csv += id + "," + name + "\n"
+	}
+	return csv
+}
+
+func reposCSV() string {
+	csv := "id,name\n"
+	for id, name := range streamTestRepoNames {
+		csv += id + "," + name + "\n"
+	}
+	return csv
+}
+
+func eventsCSV(events []*Event) string {
+	csv := "id,type,actorId,repoId\n"
+	for _, e := range events {
+		csv += e.ID + "," + e.Type + "," + e.ActorID + "," + e.RepoID + "\n"
+	}
+	return csv
+}
+
+func commitsCSV(commitCounts map[string]int64) string {
+	csv := "hash,message,eventId\n"
+	for eventID, count := range commitCounts {
+		for i := int64(0); i < count; i++ {
+			csv += "hash" + strconv.FormatInt(i, 10) + ",msg," + eventID + "\n"
+		}
+	}
+	return csv
+}
+
+// buildArchive tar+gzips the four CSVs under data/, in the given name order,
+// mirroring that tar doesn't guarantee any particular entry order.
+func buildArchive(t *testing.T, order []string, contents map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range order {
+		body := contents[name]
+		if err := tw.WriteHeader(&tar.Header{Name: "data/" + name, Typeflag: tar.TypeReg, Size: int64(len(body)), Mode: 0644}); err != nil {
+			t.Fatalf("tar header for %s: %v", name, err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("tar write for %s: %v", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar close: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRatingsFromTarStream_MatchesOracleAcrossEntryOrders(t *testing.T) {
+	events := syntheticEvents()
+	commitCounts := syntheticCommitCounts()
+	wantUsers, wantRepos := naiveProcessEvents(events, commitCounts)
+
+	contents := map[string]string{
+		actorsFilename:  actorsCSV(),
+		commitsFilename: commitsCSV(commitCounts),
+		eventsFilename:  eventsCSV(events),
+		reposFilename:   reposCSV(),
+	}
+
+	orders := map[string][]string{
+		"declared order": {actorsFilename, commitsFilename, eventsFilename, reposFilename},
+		"reversed":       {reposFilename, eventsFilename, commitsFilename, actorsFilename},
+		"events first":   {eventsFilename, actorsFilename, reposFilename, commitsFilename},
+	}
+
+	for name, order := range orders {
+		order := order
+		t.Run(name, func(t *testing.T) {
+			archive := buildArchive(t, order, contents)
+
+			app := &App{workers: 3, silent: true, noProgress: true}
+			usersRating, repoCommitsRating, repoWatchesRating, err := app.ratingsFromTarStream(context.Background(), bytes.NewReader(archive))
+			if err != nil {
+				t.Fatalf("ratingsFromTarStream: %v", err)
+			}
+
+			for _, user := range usersRating.Items() {
+				want, ok := wantUsers[user.ID]
+				if !ok {
+					t.Fatalf("unexpected user %s", user.ID)
+				}
+				if user.Commits != want.Commits || user.PREvents != want.PREvents {
+					t.Errorf("user %s = %+v, want commits=%d prEvents=%d", user.ID, user, want.Commits, want.PREvents)
+				}
+				if user.Username != streamTestUsernames[user.ID] {
+					t.Errorf("user %s username = %q, want %q", user.ID, user.Username, streamTestUsernames[user.ID])
+				}
+			}
+			if got, want := usersRating.Len(), len(wantUsers); got != want {
+				t.Errorf("usersRating.Len() = %d, want %d", got, want)
+			}
+
+			for _, repo := range repoCommitsRating.Items() {
+				want, ok := wantRepos[repo.ID]
+				if !ok {
+					t.Fatalf("unexpected repo %s", repo.ID)
+				}
+				if repo.Commits != want.Commits {
+					t.Errorf("repo %s commits = %d, want %d", repo.ID, repo.Commits, want.Commits)
+				}
+				if repo.Name != streamTestRepoNames[repo.ID] {
+					t.Errorf("repo %s name = %q, want %q", repo.ID, repo.Name, streamTestRepoNames[repo.ID])
+				}
+			}
+			if got, want := repoCommitsRating.Len(), len(wantRepos); got != want {
+				t.Errorf("repoCommitsRating.Len() = %d, want %d", got, want)
+			}
+
+			for _, repo := range repoWatchesRating.Items() {
+				want, ok := wantRepos[repo.ID]
+				if !ok {
+					t.Fatalf("unexpected repo %s", repo.ID)
+				}
+				if repo.WatchEvents != want.WatchEvents {
+					t.Errorf("repo %s watchEvents = %d, want %d", repo.ID, repo.WatchEvents, want.WatchEvents)
+				}
+			}
+			if got, want := repoWatchesRating.Len(), len(wantRepos); got != want {
+				t.Errorf("repoWatchesRating.Len() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRatingsFromTarStream_MissingEntryErrors(t *testing.T) {
+	contents := map[string]string{
+		actorsFilename:  actorsCSV(),
+		commitsFilename: commitsCSV(syntheticCommitCounts()),
+		eventsFilename:  eventsCSV(syntheticEvents()),
+		// reposFilename deliberately omitted
+	}
+	archive := buildArchive(t, []string{actorsFilename, commitsFilename, eventsFilename}, contents)
+
+	app := &App{workers: 1, silent: true, noProgress: true}
+	_, _, _, err := app.ratingsFromTarStream(context.Background(), bytes.NewReader(archive))
+	if err == nil {
+		t.Fatal("ratingsFromTarStream: want error for archive missing repos.csv, got nil")
+	}
+}